@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffExponencial(t *testing.T) {
+	casos := []struct {
+		tentativas int
+		esperado   time.Duration
+	}{
+		{0, 1 * time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{5, 32 * time.Second},
+		{20, 30 * time.Minute}, // muito além do teto, deve saturar em 30min
+	}
+
+	for _, c := range casos {
+		if got := backoffExponencial(c.tentativas); got != c.esperado {
+			t.Errorf("backoffExponencial(%d) = %v, esperado %v", c.tentativas, got, c.esperado)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package bounces
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+
+	"github.com/Josehpequeno/email-sender/state"
+)
+
+var (
+	recipientPattern    = regexp.MustCompile(`(?i)^(?:Original-Recipient|Final-Recipient|Original-Rcpt-To):\s*(?:rfc822;)?\s*(.+)$`)
+	dsnStatusPattern    = regexp.MustCompile(`(?i)^Status:\s*([\d.]+)`)
+	feedbackTypePattern = regexp.MustCompile(`(?i)^Feedback-Type:\s*(\S+)`)
+)
+
+// processarMensagem parses a single IMAP message looking for a
+// multipart/report body (DSN bounce or ARF feedback loop) and, if found,
+// updates the state store for the affected recipient.
+func (w *BounceWatcher) processarMensagem(msg *imap.Message, section *imap.BodySectionName) error {
+	literal := msg.GetBody(section)
+	if literal == nil {
+		return nil
+	}
+
+	m, err := mail.ReadMessage(literal)
+	if err != nil {
+		return fmt.Errorf("erro ao interpretar email: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil //não é um relatório de entrega
+	}
+
+	reader := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler partes MIME: %v", err)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch partType {
+		case "message/delivery-status":
+			raw, err := io.ReadAll(part)
+			if err != nil {
+				return fmt.Errorf("erro ao ler relatório de entrega: %v", err)
+			}
+			w.processarDSN(string(raw))
+		case "message/feedback-report":
+			raw, err := io.ReadAll(part)
+			if err != nil {
+				return fmt.Errorf("erro ao ler relatório de feedback: %v", err)
+			}
+			w.processarARF(string(raw))
+		}
+	}
+
+	return nil
+}
+
+// processarDSN trata um relatório delivery-status (RFC 3464). Apenas falhas
+// permanentes (classe de status 5.x.x) suprimem o destinatário; falhas
+// temporárias (4.x.x) são deixadas para o backoff do próprio EnviarEmail.
+func (w *BounceWatcher) processarDSN(raw string) {
+	destinatario, status := "", ""
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		linha := scanner.Text()
+		if m := recipientPattern.FindStringSubmatch(linha); m != nil {
+			destinatario = strings.TrimSpace(m[1])
+		}
+		if m := dsnStatusPattern.FindStringSubmatch(linha); m != nil {
+			status = m[1]
+		}
+	}
+
+	if destinatario == "" || !strings.HasPrefix(status, "5") {
+		return
+	}
+
+	w.registrar(destinatario, state.StatusBounced, fmt.Sprintf("bounce permanente (status %s)", status))
+}
+
+// processarARF trata um relatório de feedback loop (RFC 5965), usado por
+// provedores para informar reclamações de abuso/spam.
+func (w *BounceWatcher) processarARF(raw string) {
+	destinatario, tipo := "", ""
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		linha := scanner.Text()
+		if m := recipientPattern.FindStringSubmatch(linha); m != nil {
+			destinatario = strings.TrimSpace(m[1])
+		}
+		if m := feedbackTypePattern.FindStringSubmatch(linha); m != nil {
+			tipo = strings.ToLower(m[1])
+		}
+	}
+
+	if destinatario == "" {
+		return
+	}
+
+	w.registrar(destinatario, state.StatusComplained, fmt.Sprintf("reclamação via feedback loop (%s)", tipo))
+}
+
+// registrar atualiza o registro de estado do destinatário e acrescenta uma
+// linha à lista de supressão em CSV.
+func (w *BounceWatcher) registrar(destinatario string, status state.Status, motivo string) {
+	rec, found, err := w.store.FindByRecipient(w.campaignID, destinatario)
+	if err != nil {
+		log.Printf("⚠️ erro ao consultar estado de %s: %v", destinatario, err)
+	}
+	if !found {
+		// Sem Record prévio nesta campanha: a chave precisa ser derivada da
+		// mesma matrícula que EnviarEmail usará, não do endereço de email,
+		// ou a supressão cairia numa chave que o envio nunca consulta. Se o
+		// destinatário não estiver no lote atual (ex.: já saiu do CSV), não
+		// há matrícula para derivar a chave correta; registramos mesmo
+		// assim sob uma chave best-effort para não perder o bounce/ARF.
+		matricula, ok := w.matriculaPorEmail[destinatario]
+		if !ok {
+			matricula = destinatario
+		}
+		rec.MessageID = state.MessageID(w.campaignID, matricula)
+	}
+	rec.Recipient = destinatario
+	rec.Status = status
+	rec.LastError = motivo
+	rec.NextRetry = time.Time{}
+
+	if err := w.store.Put(w.campaignID, rec); err != nil {
+		log.Printf("⚠️ erro ao persistir estado de %s: %v", destinatario, err)
+		return
+	}
+
+	if err := w.adicionarSupressao(destinatario, status, motivo); err != nil {
+		log.Printf("⚠️ erro ao atualizar lista de supressão: %v", err)
+	}
+}
+
+// adicionarSupressao acrescenta uma linha ao CSV de supressão, criando o
+// cabeçalho se o arquivo ainda não existir.
+func (w *BounceWatcher) adicionarSupressao(destinatario string, status state.Status, motivo string) error {
+	if w.cfg.SuppressionListPath == "" {
+		return nil
+	}
+
+	_, err := os.Stat(w.cfg.SuppressionListPath)
+	existe := err == nil
+
+	file, err := os.OpenFile(w.cfg.SuppressionListPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir lista de supressão: %v", err)
+	}
+	defer file.Close()
+
+	if !existe {
+		if _, err := file.WriteString("email,status,motivo,timestamp\n"); err != nil {
+			return err
+		}
+	}
+
+	linha := fmt.Sprintf("%s,%s,%q,%s\n", destinatario, status, motivo, time.Now().Format(time.RFC3339))
+	_, err = file.WriteString(linha)
+	return err
+}
@@ -0,0 +1,115 @@
+package bounces
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Josehpequeno/email-sender/state"
+)
+
+// storeFalso é um state.Store em memória usado só nestes testes.
+type storeFalso struct {
+	mu      sync.Mutex
+	records map[string]map[string]state.Record // campaignID -> messageID -> Record
+}
+
+func novoStoreFalso() *storeFalso {
+	return &storeFalso{records: make(map[string]map[string]state.Record)}
+}
+
+func (s *storeFalso) Get(campaignID, messageID string) (state.Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[campaignID][messageID]
+	return rec, ok, nil
+}
+
+func (s *storeFalso) FindByRecipient(campaignID, recipient string) (state.Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range s.records[campaignID] {
+		if rec.Recipient == recipient {
+			return rec, true, nil
+		}
+	}
+	return state.Record{}, false, nil
+}
+
+func (s *storeFalso) Put(campaignID string, rec state.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[campaignID] == nil {
+		s.records[campaignID] = make(map[string]state.Record)
+	}
+	s.records[campaignID][rec.MessageID] = rec
+	return nil
+}
+
+func (s *storeFalso) Close() error { return nil }
+
+func TestProcessarDSNBounceDefinitivoSuprimeDestinatario(t *testing.T) {
+	store := novoStoreFalso()
+	const campaignID = "camp1"
+	w := &BounceWatcher{
+		store:             store,
+		campaignID:        campaignID,
+		matriculaPorEmail: map[string]string{"aluno@escola.edu": "2024001"},
+	}
+
+	raw := "Final-Recipient: rfc822; aluno@escola.edu\nStatus: 5.1.1\n"
+	w.processarDSN(raw)
+
+	messageID := state.MessageID(campaignID, "2024001")
+	rec, found, err := store.Get(campaignID, messageID)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !found {
+		t.Fatalf("registro não foi criado na chave esperada (derivada da matrícula)")
+	}
+	if rec.Status != state.StatusBounced {
+		t.Errorf("status = %q, esperado %q", rec.Status, state.StatusBounced)
+	}
+}
+
+func TestProcessarDSNStatusTemporarioNaoSuprime(t *testing.T) {
+	store := novoStoreFalso()
+	w := &BounceWatcher{store: store, campaignID: "camp1", matriculaPorEmail: map[string]string{}}
+
+	w.processarDSN("Final-Recipient: rfc822; aluno@escola.edu\nStatus: 4.2.1\n")
+
+	if len(store.records["camp1"]) != 0 {
+		t.Errorf("bounce temporário (4.x.x) não deveria gravar estado nenhum")
+	}
+}
+
+func TestProcessarARFRegistraReclamacao(t *testing.T) {
+	store := novoStoreFalso()
+	const campaignID = "camp1"
+	w := &BounceWatcher{
+		store:             store,
+		campaignID:        campaignID,
+		matriculaPorEmail: map[string]string{"aluno@escola.edu": "2024001"},
+	}
+
+	w.processarARF("Final-Recipient: rfc822; aluno@escola.edu\nFeedback-Type: abuse\n")
+
+	messageID := state.MessageID(campaignID, "2024001")
+	rec, found, _ := store.Get(campaignID, messageID)
+	if !found || rec.Status != state.StatusComplained {
+		t.Errorf("reclamação não foi registrada como %q sob a chave da matrícula", state.StatusComplained)
+	}
+}
+
+func TestRegistrarSemMatriculaConhecidaUsaFallback(t *testing.T) {
+	store := novoStoreFalso()
+	const campaignID = "camp1"
+	// destinatário fora do lote atual: não há matrícula conhecida.
+	w := &BounceWatcher{store: store, campaignID: campaignID, matriculaPorEmail: map[string]string{}}
+
+	w.registrar("desconhecido@escola.edu", state.StatusBounced, "bounce permanente (status 5.1.1)")
+
+	if len(store.records[campaignID]) != 1 {
+		t.Fatalf("esperava 1 registro gravado sob a chave de fallback, veio %d", len(store.records[campaignID]))
+	}
+}
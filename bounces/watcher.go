@@ -0,0 +1,103 @@
+// Package bounces watches a mailbox for delivery-status (DSN) and abuse
+// feedback-loop (ARF) reports and feeds the results back into the campaign
+// state store, so future sends skip bounced or complained recipients.
+package bounces
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+
+	"github.com/Josehpequeno/email-sender/state"
+)
+
+// Config holds the IMAP connection details for a BounceWatcher. Polling
+// cadence is the caller's responsibility (see ProcessarBounces in main.go,
+// called once per send/cron firing) rather than the watcher's own.
+type Config struct {
+	Host                string
+	Port                int
+	User                string
+	Password            string
+	TLS                 bool
+	SuppressionListPath string
+}
+
+func (c Config) addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// BounceWatcher periodically scans a mailbox's INBOX for DSN/ARF reports and
+// records bounces/complaints in a state.Store.
+type BounceWatcher struct {
+	cfg               Config
+	store             state.Store
+	campaignID        string
+	matriculaPorEmail map[string]string
+}
+
+// NewBounceWatcher builds a BounceWatcher for the given campaign.
+// matriculaPorEmail maps each recipient's email address to the matricula
+// used to key its state.Record (see state.MessageID); it must come from the
+// same roster the campaign is sending to, so a bounce/complaint lands under
+// the exact key EnviarEmail will later look up, even if no Record exists yet
+// for that recipient in this campaign.
+func NewBounceWatcher(cfg Config, store state.Store, campaignID string, matriculaPorEmail map[string]string) *BounceWatcher {
+	return &BounceWatcher{cfg: cfg, store: store, campaignID: campaignID, matriculaPorEmail: matriculaPorEmail}
+}
+
+// Poll connects once, scans every unseen message in INBOX for DSN/ARF
+// reports, and updates the state store accordingly.
+func (w *BounceWatcher) Poll() error {
+	c, err := w.dial()
+	if err != nil {
+		return fmt.Errorf("erro ao conectar via IMAP: %v", err)
+	}
+	defer c.Logout()
+
+	if err := c.Login(w.cfg.User, w.cfg.Password); err != nil {
+		return fmt.Errorf("erro ao autenticar via IMAP: %v", err)
+	}
+
+	if _, err := c.Select("INBOX", false); err != nil {
+		return fmt.Errorf("erro ao selecionar INBOX: %v", err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	ids, err := c.Search(criteria)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar mensagens: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(ids...)
+
+	section := &imap.BodySectionName{}
+	messages := make(chan *imap.Message, len(ids))
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Fetch(seqset, []imap.FetchItem{section.FetchItem()}, messages)
+	}()
+
+	for msg := range messages {
+		if err := w.processarMensagem(msg, section); err != nil {
+			log.Printf("⚠️ erro ao processar mensagem de bounce: %v", err)
+		}
+	}
+
+	return <-done
+}
+
+func (w *BounceWatcher) dial() (*client.Client, error) {
+	if w.cfg.TLS {
+		return client.DialTLS(w.cfg.addr(), &tls.Config{ServerName: w.cfg.Host})
+	}
+	return client.Dial(w.cfg.addr())
+}
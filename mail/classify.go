@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+)
+
+// TemporaryCode reports whether err carries an SMTP reply code in the 4xx
+// range, i.e. a transient failure that is safe to retry later.
+func TemporaryCode(err error) (code int, ok bool) {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 400 && tpErr.Code < 500 {
+		return tpErr.Code, true
+	}
+	return 0, false
+}
+
+// PermanentCode reports whether err carries an SMTP reply code in the 5xx
+// range, i.e. a permanent failure (e.g. unknown mailbox) that must never be
+// retried.
+func PermanentCode(err error) (code int, ok bool) {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 && tpErr.Code < 600 {
+		return tpErr.Code, true
+	}
+	return 0, false
+}
+
+// httpStatusError adapta o status HTTP de uma API de provedor (Mailgun, SES)
+// para um *textproto.Error, o mesmo tipo usado pelos provedores SMTP brutos,
+// para que TemporaryCode/PermanentCode também classifiquem essas falhas.
+// Segue a convenção de retry do SMTP, não a do HTTP: 5xx e 429 (erro do lado
+// do provedor ou limite de taxa) são transitórios e viram um 4xx sintético;
+// os demais 4xx (ex.: 400 endereço rejeitado) são permanentes e viram um 5xx
+// sintético.
+func httpStatusError(provider string, statusCode int, destinatario string) error {
+	codigo := 550
+	if statusCode >= 500 || statusCode == http.StatusTooManyRequests {
+		codigo = 450
+	}
+	return &textproto.Error{
+		Code: codigo,
+		Msg:  fmt.Sprintf("%s retornou status %d para %s", provider, statusCode, destinatario),
+	}
+}
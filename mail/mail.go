@@ -0,0 +1,25 @@
+// Package mail abstracts the outgoing delivery transport so EmailSender can
+// switch between raw SMTP and HTTP-API providers through config alone.
+package mail
+
+import "context"
+
+// Message is a transport-agnostic representation of an outgoing email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string // rendered HTML body
+	Headers map[string]string
+
+	// Raw, when set, is a complete RFC 5322 message (as produced by
+	// MessageBuilder, possibly DKIM-signed) that providers must send
+	// verbatim instead of composing their own headers/body from the fields
+	// above.
+	Raw []byte
+}
+
+// Provider delivers a single Message through a concrete backend.
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MailgunProvider delivers mail through the Mailgun HTTP API instead of SMTP.
+type MailgunProvider struct {
+	Domain  string
+	APIKey  string
+	BaseURL string // e.g. https://api.mailgun.net/v3 (or the EU region endpoint)
+	client  *http.Client
+}
+
+// NewMailgunProvider builds a Mailgun HTTP-API Provider. An empty baseURL
+// defaults to Mailgun's US region endpoint.
+func NewMailgunProvider(domain, apiKey, baseURL string) *MailgunProvider {
+	if baseURL == "" {
+		baseURL = "https://api.mailgun.net/v3"
+	}
+	return &MailgunProvider{
+		Domain:  domain,
+		APIKey:  apiKey,
+		BaseURL: baseURL,
+		client:  &http.Client{},
+	}
+}
+
+func (p *MailgunProvider) Send(ctx context.Context, msg Message) error {
+	if len(msg.Raw) > 0 {
+		return p.sendRaw(ctx, msg)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/messages", p.BaseURL, p.Domain)
+
+	form := url.Values{}
+	form.Set("from", msg.From)
+	form.Set("to", msg.To)
+	form.Set("subject", msg.Subject)
+	form.Set("html", msg.Body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+	req.SetBasicAuth("api", p.APIKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return p.do(req, msg.To)
+}
+
+// sendRaw posts an already-composed (and possibly DKIM-signed) RFC 5322
+// message through Mailgun's messages.mime endpoint, preserving it byte for
+// byte instead of having Mailgun rebuild it from discrete fields.
+func (p *MailgunProvider) sendRaw(ctx context.Context, msg Message) error {
+	endpoint := fmt.Sprintf("%s/%s/messages.mime", p.BaseURL, p.Domain)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("to", msg.To); err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+	part, err := writer.CreateFormFile("message", "message.mime")
+	if err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+	if _, err := part.Write(msg.Raw); err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("erro ao montar requisição Mailgun: %v", err)
+	}
+	req.SetBasicAuth("api", p.APIKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return p.do(req, msg.To)
+}
+
+func (p *MailgunProvider) do(req *http.Request, to string) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar API do Mailgun: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return httpStatusError("Mailgun", resp.StatusCode, to)
+	}
+	return nil
+}
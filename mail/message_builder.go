@@ -0,0 +1,172 @@
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// DKIMConfig holds the key material used to sign outgoing messages.
+type DKIMConfig struct {
+	Domain        string
+	Selector      string
+	PrivateKeyPEM []byte
+}
+
+// BuilderConfig configures the optional headers MessageBuilder adds to every
+// message. Zero-value fields omit the corresponding header.
+type BuilderConfig struct {
+	ListUnsubscribe     string
+	ListUnsubscribePost string
+	ReplyTo             string
+	ReturnPath          string
+	DKIM                *DKIMConfig // nil disables signing
+}
+
+// MessageBuilder turns a Message into an RFC 5322 multipart/alternative
+// document (HTML plus an auto-generated text/plain fallback), with the
+// headers providers expect, optionally DKIM-signed.
+type MessageBuilder struct {
+	cfg  BuilderConfig
+	dkim crypto.Signer // parsed once, nil if DKIM is disabled
+}
+
+// NewMessageBuilder validates cfg.DKIM's key material (if present) and
+// returns a ready-to-use MessageBuilder.
+func NewMessageBuilder(cfg BuilderConfig) (*MessageBuilder, error) {
+	b := &MessageBuilder{cfg: cfg}
+
+	if cfg.DKIM != nil {
+		signer, err := parseRSAPrivateKey(cfg.DKIM.PrivateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar chave privada DKIM: %v", err)
+		}
+		b.dkim = signer
+	}
+
+	return b, nil
+}
+
+// Build renders msg as a complete, standards-compliant RFC 5322 message and,
+// if DKIM is configured, signs it. The result is meant to be sent verbatim
+// (Message.Raw) by the chosen Provider.
+func (b *MessageBuilder) Build(msg Message) ([]byte, error) {
+	boundary := fmt.Sprintf("alt-%s", randomToken())
+	messageID := fmt.Sprintf("<%s@%s>", randomToken(), addressDomain(msg.From))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if b.cfg.ReplyTo != "" {
+		fmt.Fprintf(&buf, "Reply-To: %s\r\n", b.cfg.ReplyTo)
+	}
+	if b.cfg.ReturnPath != "" {
+		fmt.Fprintf(&buf, "Return-Path: %s\r\n", b.cfg.ReturnPath)
+	}
+	if b.cfg.ListUnsubscribe != "" {
+		fmt.Fprintf(&buf, "List-Unsubscribe: %s\r\n", b.cfg.ListUnsubscribe)
+	}
+	if b.cfg.ListUnsubscribePost != "" {
+		fmt.Fprintf(&buf, "List-Unsubscribe-Post: %s\r\n", b.cfg.ListUnsubscribePost)
+	}
+	for k, v := range msg.Headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n", boundary)
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+	buf.WriteString(htmlToText(msg.Body))
+	buf.WriteString("\r\n\r\n")
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+	buf.WriteString(msg.Body)
+	buf.WriteString("\r\n\r\n")
+
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	raw := buf.Bytes()
+	if b.dkim == nil {
+		return raw, nil
+	}
+
+	var signed bytes.Buffer
+	options := &dkim.SignOptions{
+		Domain:   b.cfg.DKIM.Domain,
+		Selector: b.cfg.DKIM.Selector,
+		Signer:   b.dkim,
+	}
+	if err := dkim.Sign(&signed, bytes.NewReader(raw), options); err != nil {
+		return nil, fmt.Errorf("erro ao assinar DKIM: %v", err)
+	}
+
+	return signed.Bytes(), nil
+}
+
+func parseRSAPrivateKey(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("chave privada DKIM inválida (PEM)")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("chave privada DKIM em formato não suportado: %v", err)
+	}
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("chave privada DKIM precisa ser RSA")
+	}
+	return rsaKey, nil
+}
+
+func randomToken() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func addressDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i != -1 {
+		return address[i+1:]
+	}
+	return address
+}
+
+var htmlTagPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// htmlToText produces a best-effort plaintext fallback from a rendered HTML
+// body, for clients/filters that prefer text/plain.
+func htmlToText(body string) string {
+	semTags := htmlTagPattern.ReplaceAllString(body, "")
+	texto := html.UnescapeString(semTags)
+
+	linhas := strings.Split(texto, "\n")
+	for i, linha := range linhas {
+		linhas[i] = strings.TrimSpace(linha)
+	}
+	return strings.Join(linhas, "\n")
+}
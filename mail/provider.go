@@ -0,0 +1,38 @@
+package mail
+
+import "fmt"
+
+// ProviderConfig carries every field needed to construct any supported
+// Provider; NewProvider only reads the fields relevant to Type.
+type ProviderConfig struct {
+	Type string // smtp|smtp_tls|smtp_implicit_tls|mailgun|ses
+
+	SMTP SMTPConfig
+
+	MailgunDomain  string
+	MailgunAPIKey  string
+	MailgunBaseURL string
+
+	SESRegion          string
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+}
+
+// NewProvider builds the concrete Provider selected by cfg.Type. An empty
+// Type defaults to plain SMTP to preserve the previous behavior.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Type {
+	case "", "smtp":
+		return NewSMTPProvider(cfg.SMTP), nil
+	case "smtp_tls":
+		return NewSMTPTLSProvider(cfg.SMTP), nil
+	case "smtp_implicit_tls":
+		return NewSMTPImplicitTLSProvider(cfg.SMTP), nil
+	case "mailgun":
+		return NewMailgunProvider(cfg.MailgunDomain, cfg.MailgunAPIKey, cfg.MailgunBaseURL), nil
+	case "ses":
+		return NewSESProvider(cfg.SESRegion, cfg.SESAccessKeyID, cfg.SESSecretAccessKey), nil
+	default:
+		return nil, fmt.Errorf("transporte de email desconhecido: %s", cfg.Type)
+	}
+}
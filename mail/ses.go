@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SESProvider delivers mail through the Amazon SES Query API, signed with
+// AWS Signature Version 4. It talks to the API directly instead of pulling
+// in the full AWS SDK, keeping the sender a single dependency-light binary.
+type SESProvider struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	client          *http.Client
+}
+
+// NewSESProvider builds an Amazon SES Provider for the given region.
+func NewSESProvider(region, accessKeyID, secretAccessKey string) *SESProvider {
+	return &SESProvider{
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		client:          &http.Client{},
+	}
+}
+
+func (p *SESProvider) endpoint() string {
+	return fmt.Sprintf("email.%s.amazonaws.com", p.Region)
+}
+
+func (p *SESProvider) Send(ctx context.Context, msg Message) error {
+	form := url.Values{}
+	if len(msg.Raw) > 0 {
+		// SendRawEmail preserves the message (and any DKIM signature) byte
+		// for byte instead of having SES rebuild it from discrete fields.
+		form.Set("Action", "SendRawEmail")
+		form.Set("Version", "2010-12-01")
+		form.Set("Destinations.member.1", msg.To)
+		form.Set("RawMessage.Data", base64.StdEncoding.EncodeToString(msg.Raw))
+	} else {
+		form.Set("Action", "SendEmail")
+		form.Set("Version", "2010-12-01")
+		form.Set("Source", msg.From)
+		form.Set("Destination.ToAddresses.member.1", msg.To)
+		form.Set("Message.Subject.Data", msg.Subject)
+		form.Set("Message.Body.Html.Data", msg.Body)
+	}
+	payload := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+p.endpoint()+"/", bytes.NewBufferString(payload))
+	if err != nil {
+		return fmt.Errorf("erro ao montar requisição SES: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	p.sign(req, payload)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar API do SES: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return httpStatusError("SES", resp.StatusCode, msg.To)
+	}
+	return nil
+}
+
+// sign implements AWS Signature Version 4 for the SES query API. See
+// https://docs.aws.amazon.com/general/latest/gr/sigv4-signed-request-examples.html
+func (p *SESProvider) sign(req *http.Request, payload string) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-date:%s\n", p.endpoint(), amzDate)
+	signedHeaders := "host;x-amz-date"
+	payloadHash := sha256Hex(payload)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := fmt.Sprintf("%s/%s/ses/aws4_request", dateStamp, p.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	signingKey := signingKey(p.SecretAccessKey, dateStamp, p.Region, "ses")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.AccessKeyID, credentialScope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func signingKey(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
@@ -0,0 +1,146 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPConfig holds the connection details shared by every net/smtp based
+// provider (plain, STARTTLS and implicit TLS).
+type SMTPConfig struct {
+	Host               string
+	Port               int
+	Email              string
+	Password           string
+	InsecureSkipVerify bool
+}
+
+func (c SMTPConfig) addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// SMTPProvider sends mail via plain SMTP AUTH PLAIN, with no TLS negotiation
+// of its own (suitable for servers that only accept it on localhost/VPN).
+type SMTPProvider struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPProvider builds a plain-SMTP Provider.
+func NewSMTPProvider(cfg SMTPConfig) *SMTPProvider {
+	return &SMTPProvider{cfg: cfg}
+}
+
+func (p *SMTPProvider) Send(ctx context.Context, msg Message) error {
+	auth := smtp.PlainAuth("", p.cfg.Email, p.cfg.Password, p.cfg.Host)
+	return smtp.SendMail(p.cfg.addr(), auth, p.cfg.Email, []string{msg.To}, buildRaw(msg))
+}
+
+// SMTPTLSProvider upgrades a plaintext connection with STARTTLS before
+// authenticating (the common port-587 submission flow).
+type SMTPTLSProvider struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPTLSProvider builds an SMTP+STARTTLS Provider.
+func NewSMTPTLSProvider(cfg SMTPConfig) *SMTPTLSProvider {
+	return &SMTPTLSProvider{cfg: cfg}
+}
+
+func (p *SMTPTLSProvider) Send(ctx context.Context, msg Message) error {
+	conn, err := net.Dial("tcp", p.cfg.addr())
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no servidor SMTP: %v", err)
+	}
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar cliente SMTP: %v", err)
+	}
+	defer client.Close()
+
+	tlsConfig := &tls.Config{
+		ServerName:         p.cfg.Host,
+		InsecureSkipVerify: p.cfg.InsecureSkipVerify,
+	}
+	if err := client.StartTLS(tlsConfig); err != nil {
+		return fmt.Errorf("erro ao negociar STARTTLS: %v", err)
+	}
+
+	return authAndSend(client, p.cfg, msg)
+}
+
+// SMTPImplicitTLSProvider dials straight into TLS, as used by port 465.
+type SMTPImplicitTLSProvider struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPImplicitTLSProvider builds an implicit-TLS SMTP Provider.
+func NewSMTPImplicitTLSProvider(cfg SMTPConfig) *SMTPImplicitTLSProvider {
+	return &SMTPImplicitTLSProvider{cfg: cfg}
+}
+
+func (p *SMTPImplicitTLSProvider) Send(ctx context.Context, msg Message) error {
+	tlsConfig := &tls.Config{
+		ServerName:         p.cfg.Host,
+		InsecureSkipVerify: p.cfg.InsecureSkipVerify,
+	}
+
+	conn, err := tls.Dial("tcp", p.cfg.addr(), tlsConfig)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar via TLS implícito: %v", err)
+	}
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar cliente SMTP: %v", err)
+	}
+	defer client.Close()
+
+	return authAndSend(client, p.cfg, msg)
+}
+
+func authAndSend(client *smtp.Client, cfg SMTPConfig, msg Message) error {
+	auth := smtp.PlainAuth("", cfg.Email, cfg.Password, cfg.Host)
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("erro de autenticação SMTP: %v", err)
+	}
+
+	if err := client.Mail(cfg.Email); err != nil {
+		return fmt.Errorf("erro no comando MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("erro no comando RCPT TO: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir stream de dados: %w", err)
+	}
+	if _, err := w.Write(buildRaw(msg)); err != nil {
+		return fmt.Errorf("erro ao escrever corpo do email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildRaw composes a minimal RFC 5322 message from msg's fields. If msg.Raw
+// is already set (by a MessageBuilder), that is used verbatim instead.
+func buildRaw(msg Message) []byte {
+	if len(msg.Raw) > 0 {
+		return msg.Raw
+	}
+
+	raw := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n", msg.From, msg.To, msg.Subject)
+	for k, v := range msg.Headers {
+		raw += fmt.Sprintf("%s: %s\r\n", k, v)
+	}
+	raw += "MIME-Version: 1.0\r\nContent-Type: text/html; charset=\"UTF-8\"\r\n\r\n"
+	raw += msg.Body
+	return []byte(raw)
+}
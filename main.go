@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
-	"net/smtp"
 	"os"
+	"os/signal"
+	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Josehpequeno/email-sender/bounces"
+	"github.com/Josehpequeno/email-sender/mail"
+	"github.com/Josehpequeno/email-sender/scheduler"
+	"github.com/Josehpequeno/email-sender/state"
+	"github.com/Josehpequeno/email-sender/tracking"
+	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v3"
 )
 
@@ -24,17 +35,95 @@ type Config struct {
 		Password string `yaml:"password"`
 	} `yaml:"smtp"`
 
-	Limits struct {
-		DailyEmailLimit int `yaml:"daily_limit"`
-		DelaySeconds    int `yaml:"delay_seconds"`
-		BatchSize       int `yaml:"batch_size"`
-		Workers         int `yaml:"workers"`
-	} `yaml:"limits"`
+	Transport struct {
+		Type       string `yaml:"type"`        // smtp|smtp_tls|smtp_implicit_tls|mailgun|ses
+		DailyQuota int    `yaml:"daily_quota"` // limite diário específico do provedor (ex.: Gmail 500, SES sandbox 200); 0 usa limits.daily_limit
+		TLS        struct {
+			InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+		} `yaml:"tls"`
+		Mailgun struct {
+			Domain  string `yaml:"domain"`
+			APIKey  string `yaml:"api_key"`
+			BaseURL string `yaml:"base_url"`
+		} `yaml:"mailgun"`
+		SES struct {
+			Region          string `yaml:"region"`
+			AccessKeyID     string `yaml:"access_key_id"`
+			SecretAccessKey string `yaml:"secret_access_key"`
+		} `yaml:"ses"`
+	} `yaml:"transport"`
+
+	Limits LimitsConfig `yaml:"limits"`
 
 	Email struct {
-		Subject  string `yaml:"subject"`
-		Template string `yaml:"template"`
+		Subject             string `yaml:"subject"`
+		Template            string `yaml:"template"`
+		ReplyTo             string `yaml:"reply_to"`
+		ReturnPath          string `yaml:"return_path"`
+		ListUnsubscribe     string `yaml:"list_unsubscribe"`
+		ListUnsubscribePost string `yaml:"list_unsubscribe_post"`
 	} `yaml:"email"`
+
+	DKIM struct {
+		Domain         string `yaml:"domain"`
+		Selector       string `yaml:"selector"`
+		PrivateKeyPath string `yaml:"private_key_path"`
+	} `yaml:"dkim"`
+
+	Campaign struct {
+		ID string `yaml:"id"`
+	} `yaml:"campaign"`
+
+	State struct {
+		Path string `yaml:"path"`
+	} `yaml:"state"`
+
+	IMAP struct {
+		Host                string `yaml:"host"`
+		Port                int    `yaml:"port"`
+		User                string `yaml:"user"`
+		Password            string `yaml:"password"`
+		TLS                 bool   `yaml:"tls"`
+		SuppressionListPath string `yaml:"suppression_list_path"`
+	} `yaml:"imap"`
+
+	Tracking struct {
+		Enabled     bool   `yaml:"enabled"`
+		BaseURL     string `yaml:"base_url"` // ex.: https://track.exemplo.edu.br, sem barra final
+		ListenAddr  string `yaml:"listen_addr"`
+		TLSCertPath string `yaml:"tls_cert_path"`
+		TLSKeyPath  string `yaml:"tls_key_path"`
+		HMACSecret  string `yaml:"hmac_secret"`
+	} `yaml:"tracking"`
+
+	// Campaigns, quando presente, tira o programa do modo de disparo único e
+	// o transforma em um serviço de longa duração: cada campanha roda no seu
+	// próprio agendamento cron, com CSV, template, assunto e limites
+	// próprios, compartilhando o mesmo transporte e armazenamento de estado.
+	Campaigns []CampaignDef `yaml:"campaigns"`
+}
+
+// LimitsConfig controla cota diária, teto por hora e o limitador de taxa
+// (token bucket) usados ao enviar um lote de emails.
+type LimitsConfig struct {
+	DailyEmailLimit int     `yaml:"daily_limit"`
+	HourlyCap       int     `yaml:"hourly_cap"`
+	EmailsPerSecond float64 `yaml:"emails_per_second"`
+	Burst           int     `yaml:"burst"`
+	BatchSize       int     `yaml:"batch_size"`
+	Workers         int     `yaml:"workers"`
+}
+
+// CampaignDef descreve uma campanha recorrente agendada via cron, com seu
+// próprio CSV, template, assunto e limites; campos de Limits deixados em
+// zero herdam o valor correspondente de Config.Limits.
+type CampaignDef struct {
+	Name     string       `yaml:"name"`
+	Schedule string       `yaml:"schedule"`
+	CSVPath  string       `yaml:"csv"`
+	Subject  string       `yaml:"subject"`
+	Template string       `yaml:"template"`
+	Limits   LimitsConfig `yaml:"limits"`
 }
 
 type Aluno struct {
@@ -43,6 +132,10 @@ type Aluno struct {
 	Matricula          string `json:"matricula"`
 	Nome               string `json:"nome"`
 	EmailInstitucional string `json:"email_institucional"`
+	// DoNotTrack vem da coluna opcional "do_not_track" do CSV; quando
+	// verdadeira, GerarCorpoEmail não reescreve links nem anexa o pixel de
+	// abertura para este aluno.
+	DoNotTrack bool `json:"do_not_track,omitempty"`
 }
 
 type Resultado struct {
@@ -50,16 +143,30 @@ type Resultado struct {
 	Aluno     Aluno     `json:"aluno"`
 	Status    string    `json:"status"`
 	Error     string    `json:"error,omitempty"`
+	// OpenedAt e Clicks refletem o estado de tracking no momento em que o
+	// relatório foi gerado (ver GerarRelatorio); podem ficar vazios se o
+	// destinatário ainda não abriu o email ou clicou em nenhum link.
+	OpenedAt time.Time          `json:"opened_at,omitempty"`
+	Clicks   []state.ClickEvent `json:"clicks,omitempty"`
 }
 
 type EmailSender struct {
 	config     Config
 	template   *template.Template
+	provider   mail.Provider
+	builder    *mail.MessageBuilder
+	store      state.Store
+	campaignID string
+	limiter    *rate.Limiter
+	limiterMu  sync.Mutex
 	resultados []Resultado
 	mutex      sync.Mutex
 	contadores struct {
-		enviados int
-		falhas   int
+		enviados   int
+		falhas     int
+		hora       int
+		horaInicio time.Time
+		diaInicio  time.Time
 	}
 }
 
@@ -70,19 +177,200 @@ func NewEmailSender(configPath string) (*EmailSender, error) {
 		return nil, fmt.Errorf("erro ao carregar configuração: %v", err)
 	}
 
-	// carrega template
-	tmpl, err := template.ParseFiles(config.Email.Template)
+	provider, builder, store, err := buildDependenciasCompartilhadas(config)
+	if err != nil {
+		return nil, err
+	}
+
+	campaignID := config.Campaign.ID
+	if campaignID == "" {
+		campaignID = "default"
+	}
+
+	return newEmailSender(config, campaignID, config.Email.Template, config.Limits, provider, builder, store)
+}
+
+// buildDependenciasCompartilhadas constrói o transporte, o construtor de
+// mensagens e o armazenamento de estado a partir do Config raiz. Essas três
+// dependências são caras (conexões, chaves, arquivo de banco) e por isso são
+// compartilhadas por todas as campanhas de um mesmo processo, mesmo quando
+// há várias campanhas agendadas.
+func buildDependenciasCompartilhadas(config Config) (mail.Provider, *mail.MessageBuilder, state.Store, error) {
+	provider, err := mail.NewProvider(providerConfig(config))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("erro ao configurar transporte de email: %v", err)
+	}
+
+	builder, err := newMessageBuilder(config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("erro ao configurar construtor de mensagens: %v", err)
+	}
+
+	statePath := config.State.Path
+	if statePath == "" {
+		statePath = "state.db"
+	}
+	store, err := state.OpenBoltStore(statePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("erro ao abrir armazenamento de estado: %v", err)
+	}
+
+	return provider, builder, store, nil
+}
+
+// newEmailSender monta um EmailSender para a campanha campaignID, com o
+// template e os limites informados, reutilizando um transporte, construtor
+// de mensagens e armazenamento de estado já construídos.
+func newEmailSender(config Config, campaignID, templatePath string, limits LimitsConfig, provider mail.Provider, builder *mail.MessageBuilder, store state.Store) (*EmailSender, error) {
+	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao carregar template de email: %v", err)
 	}
 
+	emailsPorSegundo := limits.EmailsPerSecond
+	if emailsPorSegundo <= 0 {
+		emailsPorSegundo = 1
+	}
+	burst := limits.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	config.Limits = limits
+
 	return &EmailSender{
 		config:     config,
 		template:   tmpl,
+		provider:   provider,
+		builder:    builder,
+		store:      store,
+		campaignID: campaignID,
+		limiter:    rate.NewLimiter(rate.Limit(emailsPorSegundo), burst),
 		resultados: make([]Resultado, 0),
 	}, nil
 }
 
+// newCampaignSender monta o EmailSender de uma campanha agendada (CampaignDef),
+// herdando de base qualquer campo de Limits deixado em zero na própria
+// campanha, e usando o assunto e template declarados nela.
+func newCampaignSender(base Config, def CampaignDef, provider mail.Provider, builder *mail.MessageBuilder, store state.Store) (*EmailSender, error) {
+	limits := base.Limits
+	if def.Limits.DailyEmailLimit != 0 {
+		limits.DailyEmailLimit = def.Limits.DailyEmailLimit
+	}
+	if def.Limits.HourlyCap != 0 {
+		limits.HourlyCap = def.Limits.HourlyCap
+	}
+	if def.Limits.EmailsPerSecond != 0 {
+		limits.EmailsPerSecond = def.Limits.EmailsPerSecond
+	}
+	if def.Limits.Burst != 0 {
+		limits.Burst = def.Limits.Burst
+	}
+	if def.Limits.BatchSize != 0 {
+		limits.BatchSize = def.Limits.BatchSize
+	}
+	if def.Limits.Workers != 0 {
+		limits.Workers = def.Limits.Workers
+	}
+
+	config := base
+	config.Email.Subject = def.Subject
+
+	return newEmailSender(config, def.Name, def.Template, limits, provider, builder, store)
+}
+
+// limiteDiarioEfetivo retorna a cota diária do provedor de transporte
+// configurado quando definida, ou o limite geral de limits.daily_limit.
+func (es *EmailSender) limiteDiarioEfetivo() int {
+	if es.config.Transport.DailyQuota > 0 {
+		return es.config.Transport.DailyQuota
+	}
+	return es.config.Limits.DailyEmailLimit
+}
+
+// Fechar libera os recursos do EmailSender, como o armazenamento de estado.
+func (es *EmailSender) Fechar() error {
+	return es.store.Close()
+}
+
+// ProcessarBounces consulta a caixa de entrada configurada em `imap` em
+// busca de relatórios de bounce/reclamação e atualiza o armazenamento de
+// estado, para que a campanha atual e as futuras pulem esses destinatários.
+// alunos é o lote carregado do CSV desta execução; seu email institucional é
+// usado para traduzir o endereço de um DSN/ARF na mesma matrícula usada por
+// EnviarEmail, garantindo que a supressão caia na chave correta mesmo
+// quando ainda não há nenhum Record para o destinatário nesta campanha. Não
+// faz nada se `imap.host` não estiver configurado.
+func (es *EmailSender) ProcessarBounces(alunos []Aluno) error {
+	if es.config.IMAP.Host == "" {
+		return nil
+	}
+
+	matriculaPorEmail := make(map[string]string, len(alunos))
+	for _, aluno := range alunos {
+		matriculaPorEmail[aluno.EmailInstitucional] = aluno.Matricula
+	}
+
+	watcher := bounces.NewBounceWatcher(bounces.Config{
+		Host:                es.config.IMAP.Host,
+		Port:                es.config.IMAP.Port,
+		User:                es.config.IMAP.User,
+		Password:            es.config.IMAP.Password,
+		TLS:                 es.config.IMAP.TLS,
+		SuppressionListPath: es.config.IMAP.SuppressionListPath,
+	}, es.store, es.campaignID, matriculaPorEmail)
+
+	return watcher.Poll()
+}
+
+// providerConfig traduz o bloco `transport` (e as credenciais SMTP base)
+// do Config para o formato esperado pelo pacote mail.
+func providerConfig(config Config) mail.ProviderConfig {
+	return mail.ProviderConfig{
+		Type: config.Transport.Type,
+		SMTP: mail.SMTPConfig{
+			Host:               config.SMTP.Host,
+			Port:               config.SMTP.Port,
+			Email:              config.SMTP.Email,
+			Password:           config.SMTP.Password,
+			InsecureSkipVerify: config.Transport.TLS.InsecureSkipVerify,
+		},
+		MailgunDomain:      config.Transport.Mailgun.Domain,
+		MailgunAPIKey:      config.Transport.Mailgun.APIKey,
+		MailgunBaseURL:     config.Transport.Mailgun.BaseURL,
+		SESRegion:          config.Transport.SES.Region,
+		SESAccessKeyID:     config.Transport.SES.AccessKeyID,
+		SESSecretAccessKey: config.Transport.SES.SecretAccessKey,
+	}
+}
+
+// newMessageBuilder traduz os blocos `email` e `dkim` do Config para o
+// mail.MessageBuilder, carregando a chave privada DKIM do disco quando
+// configurada.
+func newMessageBuilder(config Config) (*mail.MessageBuilder, error) {
+	builderCfg := mail.BuilderConfig{
+		ReplyTo:             config.Email.ReplyTo,
+		ReturnPath:          config.Email.ReturnPath,
+		ListUnsubscribe:     config.Email.ListUnsubscribe,
+		ListUnsubscribePost: config.Email.ListUnsubscribePost,
+	}
+
+	if config.DKIM.Domain != "" {
+		chave, err := os.ReadFile(config.DKIM.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler chave privada DKIM: %v", err)
+		}
+		builderCfg.DKIM = &mail.DKIMConfig{
+			Domain:        config.DKIM.Domain,
+			Selector:      config.DKIM.Selector,
+			PrivateKeyPEM: chave,
+		}
+	}
+
+	return mail.NewMessageBuilder(builderCfg)
+}
+
 func loadConfig(configPath string) (Config, error) {
 	var config Config
 
@@ -145,6 +433,7 @@ func (es *EmailSender) CarregarAlunosCSV(csvPath string) ([]Aluno, error) {
 			Matricula:          obterValor(linha, colunas, "matricula"),
 			Nome:               obterValor(linha, colunas, "nome"),
 			EmailInstitucional: obterValor(linha, colunas, "email_institucional"),
+			DoNotTrack:         valorVerdadeiro(obterValor(linha, colunas, "do_not_track")),
 		}
 
 		//validar email
@@ -166,6 +455,17 @@ func obterValor(linha []string, colunas map[string]int, nomeColuna string) strin
 	return ""
 }
 
+// valorVerdadeiro interpreta valores comuns de colunas booleanas opcionais do
+// CSV (ex.: do_not_track); coluna ausente ou vazia é tratada como falso.
+func valorVerdadeiro(valor string) bool {
+	switch strings.ToLower(strings.TrimSpace(valor)) {
+	case "1", "true", "sim", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func (es *EmailSender) GerarCorpoEmail(aluno Aluno) (string, error) {
 	var builder strings.Builder
 
@@ -174,50 +474,176 @@ func (es *EmailSender) GerarCorpoEmail(aluno Aluno) (string, error) {
 		return "", fmt.Errorf("erro ao executar template: %v", err)
 	}
 
-	return builder.String(), nil
+	corpo := builder.String()
+	if es.config.Tracking.Enabled && !aluno.DoNotTrack {
+		messageID := state.MessageID(es.campaignID, aluno.Matricula)
+		corpo = es.reescreverParaTracking(corpo, messageID)
+	}
+
+	return corpo, nil
 }
 
-func (es *EmailSender) EnviarEmail(aluno Aluno) Resultado {
+var hrefPattern = regexp.MustCompile(`(?i)href="([^"]+)"`)
+
+// reescreverParaTracking reescreve os links de corpo para passarem pelo
+// endpoint de clique do servidor de tracking e anexa o pixel de abertura
+// 1x1. Links mailto:/tel:/âncora são deixados intactos.
+func (es *EmailSender) reescreverParaTracking(corpo, messageID string) string {
+	token := tracking.Token(es.config.Tracking.HMACSecret, messageID)
+
+	corpo = hrefPattern.ReplaceAllStringFunc(corpo, func(match string) string {
+		destino := hrefPattern.FindStringSubmatch(match)[1]
+		if strings.HasPrefix(destino, "mailto:") || strings.HasPrefix(destino, "tel:") || strings.HasPrefix(destino, "#") {
+			return match
+		}
+		u := base64.URLEncoding.EncodeToString([]byte(destino))
+		cliqueURL := fmt.Sprintf("%s/c/%s/%s?u=%s", es.config.Tracking.BaseURL, es.campaignID, token, u)
+		return fmt.Sprintf(`href="%s"`, cliqueURL)
+	})
+
+	pixel := fmt.Sprintf(`<img src="%s/o/%s/%s.gif" width="1" height="1" alt="" style="display:none" />`,
+		es.config.Tracking.BaseURL, es.campaignID, token)
+	return corpo + pixel
+}
+
+func (es *EmailSender) EnviarEmail(ctx context.Context, aluno Aluno) Resultado {
 	resultado := Resultado{
 		Timestamp: time.Now(),
 		Aluno:     aluno,
 	}
 
+	messageID := state.MessageID(es.campaignID, aluno.Matricula)
+	rec, _, err := es.store.Get(es.campaignID, messageID)
+	if err != nil {
+		log.Printf("⚠️ erro ao consultar estado de %s: %v", aluno.Matricula, err)
+	}
+	rec.MessageID = messageID
+	rec.Recipient = aluno.EmailInstitucional
+	rec.Attempts++
+
 	corpo, err := es.GerarCorpoEmail(aluno)
 	if err != nil {
 		resultado.Status = "falha"
 		resultado.Error = fmt.Sprintf("erro ao gerar corpo do email: %v", err)
 		es.registrarResultado(resultado)
+		es.registrarEstado(rec, err)
 		return resultado
 	}
 
-	//configurar mensagem
-	mensagem := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=\"UTF-8\"\r\n\r\n%s",
-		es.config.SMTP.Email,
-		aluno.EmailInstitucional,
-		es.config.Email.Subject,
-		corpo,
-	)
-
-	//configurar autenticação
-	auth := smtp.PlainAuth("", es.config.SMTP.Email, es.config.SMTP.Password, es.config.SMTP.Host)
-	// log.Println("Auth", auth)
-	// log.Printf("Email de envio: %s", es.config.SMTP.Email)
+	//montar mensagem RFC 5322 completa (multipart/alternative + DKIM)
+	msg := mail.Message{
+		From:    es.config.SMTP.Email,
+		To:      aluno.EmailInstitucional,
+		Subject: es.config.Email.Subject,
+		Body:    corpo,
+	}
+	raw, err := es.builder.Build(msg)
+	if err != nil {
+		resultado.Status = "falha"
+		resultado.Error = fmt.Sprintf("erro ao montar mensagem: %v", err)
+		es.registrarResultado(resultado)
+		es.registrarEstado(rec, err)
+		return resultado
+	}
+	msg.Raw = raw
 
-	//enviar email
-	enderecoSMTP := fmt.Sprintf("%s:%d", es.config.SMTP.Host, es.config.SMTP.Port)
-	err = smtp.SendMail(enderecoSMTP, auth, es.config.SMTP.Email, []string{aluno.EmailInstitucional}, []byte(mensagem))
+	//enviar email através do provedor de transporte configurado
+	err = es.provider.Send(ctx, msg)
 	if err != nil {
 		resultado.Status = "falha"
 		resultado.Error = fmt.Sprintf("erro ao enviar email: %v", err)
 		es.registrarResultado(resultado)
+		es.registrarEstado(rec, err)
 		return resultado
 	}
 	resultado.Status = "sucesso"
 	es.registrarResultado(resultado)
+	es.registrarEstado(rec, nil)
 	return resultado
 }
 
+// registrarEstado grava, de forma transacional, o resultado de uma tentativa
+// de envio no armazenamento persistente, para permitir retomar a campanha.
+// Falhas permanentes (5xx) marcam o destinatário como bounced e nunca mais
+// são tentadas; falhas temporárias (4xx) reduzem a taxa de envio e agendam
+// uma nova tentativa com backoff exponencial.
+func (es *EmailSender) registrarEstado(rec state.Record, erroEnvio error) {
+	switch {
+	case erroEnvio == nil:
+		rec.Status = state.StatusSent
+		rec.LastError = ""
+		rec.NextRetry = time.Time{}
+	case isPermanente(erroEnvio):
+		rec.Status = state.StatusBounced
+		rec.LastError = erroEnvio.Error()
+		rec.NextRetry = time.Time{}
+	default:
+		if _, temporario := mail.TemporaryCode(erroEnvio); temporario {
+			es.reduzirTaxa()
+		}
+		rec.Status = state.StatusFailed
+		rec.LastError = erroEnvio.Error()
+		rec.NextRetry = time.Now().Add(backoffExponencial(rec.Attempts))
+	}
+
+	if err := es.store.Put(es.campaignID, rec); err != nil {
+		log.Printf("⚠️ erro ao persistir estado de %s: %v", rec.MessageID, err)
+	}
+}
+
+func isPermanente(err error) bool {
+	_, ok := mail.PermanentCode(err)
+	return ok
+}
+
+// reduzirTaxa reduz pela metade o limite efetivo do limitador de taxa ao
+// detectar um código SMTP 4xx, recuando a velocidade de envio até que o
+// provedor se recupere.
+func (es *EmailSender) reduzirTaxa() {
+	es.limiterMu.Lock()
+	defer es.limiterMu.Unlock()
+
+	novoLimite := es.limiter.Limit() / 2
+	if novoLimite < 0.01 {
+		novoLimite = 0.01
+	}
+	es.limiter.SetLimit(novoLimite)
+	log.Printf("🐢 Código SMTP temporário detectado; taxa de envio reduzida para %.3f emails/s", float64(novoLimite))
+}
+
+// backoffExponencial calcula o atraso antes da próxima tentativa, dobrando a
+// cada falha até um teto de 30 minutos.
+func backoffExponencial(tentativas int) time.Duration {
+	atraso := time.Duration(1<<uint(tentativas)) * time.Second
+	if atraso > 30*time.Minute {
+		atraso = 30 * time.Minute
+	}
+	return atraso
+}
+
+// atualizarJanelaHora reseta o contador horário quando uma nova hora começa.
+// Deve ser chamada com es.mutex já adquirido.
+func (es *EmailSender) atualizarJanelaHora() {
+	agora := time.Now()
+	if agora.Sub(es.contadores.horaInicio) >= time.Hour {
+		es.contadores.hora = 0
+		es.contadores.horaInicio = agora
+	}
+}
+
+// atualizarJanelaDia reseta o contador diário quando um novo dia começa.
+// Sem isso, um EmailSender de vida longa (agendador) que um dia atinge
+// daily_limit/daily_quota ficaria travado nesse limite para sempre, já que
+// no modo de disparo único era o processo reiniciado a cada invocação que
+// zerava o contador de graça. Deve ser chamada com es.mutex já adquirido.
+func (es *EmailSender) atualizarJanelaDia() {
+	agora := time.Now()
+	if agora.Sub(es.contadores.diaInicio) >= 24*time.Hour {
+		es.contadores.enviados = 0
+		es.contadores.diaInicio = agora
+	}
+}
+
 func (es *EmailSender) registrarResultado(resultado Resultado) {
 	es.mutex.Lock()
 	defer es.mutex.Unlock()
@@ -226,6 +652,7 @@ func (es *EmailSender) registrarResultado(resultado Resultado) {
 
 	if resultado.Status == "sucesso" {
 		es.contadores.enviados++
+		es.contadores.hora++
 		log.Printf("✅ Email enviado para %s", resultado.Aluno.EmailInstitucional)
 	} else {
 		es.contadores.falhas++
@@ -233,14 +660,53 @@ func (es *EmailSender) registrarResultado(resultado Resultado) {
 	}
 }
 
-func (es *EmailSender) EnviarEmailsEmLote(alunos []Aluno) {
-	total := len(alunos)
-	log.Printf("🚀 Iniciando envio para %d alunos", total)
+// filtrarPendentes remove da lista quem já foi enviado com sucesso ou
+// descartado por bounce; em modo --resume também respeita o NextRetry das
+// tentativas que falharam.
+func (es *EmailSender) filtrarPendentes(alunos []Aluno, resume bool) []Aluno {
+	pendentes := make([]Aluno, 0, len(alunos))
+	agora := time.Now()
+
+	for _, aluno := range alunos {
+		messageID := state.MessageID(es.campaignID, aluno.Matricula)
+		rec, ok, err := es.store.Get(es.campaignID, messageID)
+		if err != nil {
+			log.Printf("⚠️ erro ao consultar estado de %s: %v", aluno.Matricula, err)
+			pendentes = append(pendentes, aluno)
+			continue
+		}
+		if !ok {
+			pendentes = append(pendentes, aluno)
+			continue
+		}
+
+		switch rec.Status {
+		case state.StatusSent, state.StatusBounced:
+			continue //nunca reenviar
+		case state.StatusPending, state.StatusFailed:
+			if resume && rec.NextRetry.After(agora) {
+				continue //ainda dentro do backoff
+			}
+			pendentes = append(pendentes, aluno)
+		}
+	}
+
+	return pendentes
+}
+
+// EnviarEmailsEmLote dispara o envio para os alunos pendentes. ctx cancelado
+// (por exemplo, via SIGINT/SIGTERM no modo agendador) interrompe a espera no
+// limitador de taxa e encerra os workers sem iniciar novos envios.
+func (es *EmailSender) EnviarEmailsEmLote(ctx context.Context, alunos []Aluno, resume bool) {
+	pendentes := es.filtrarPendentes(alunos, resume)
+	total := len(pendentes)
+	log.Printf("🚀 Iniciando envio para %d alunos (campanha=%s, resume=%v, ignorados=%d)",
+		total, es.campaignID, resume, len(alunos)-total)
 
 	canalAlunos := make(chan Aluno, total)
 
 	//preencher canal de alunos
-	for _, aluno := range alunos {
+	for _, aluno := range pendentes {
 		canalAlunos <- aluno
 	}
 
@@ -255,19 +721,37 @@ func (es *EmailSender) EnviarEmailsEmLote(alunos []Aluno) {
 			defer wg.Done()
 
 			for aluno := range canalAlunos {
-				//verificar limite diário
+				if ctx.Err() != nil {
+					log.Printf("🛑 Cancelamento recebido; worker %d encerrando sem enviar mais emails.", workerID)
+					return
+				}
+
+				//verificar limite diário (cota do provedor, se houver)
 				es.mutex.Lock()
-				if es.contadores.enviados >= es.config.Limits.DailyEmailLimit {
+				es.atualizarJanelaDia()
+				limiteDiario := es.limiteDiarioEfetivo()
+				if limiteDiario > 0 && es.contadores.enviados >= limiteDiario {
 					es.mutex.Unlock()
-					log.Printf("⚠️ Limite diário de %d emails atingido. Worker %d encerrando.", es.config.Limits.DailyEmailLimit, workerID)
+					log.Printf("⚠️ Limite diário de %d emails atingido. Worker %d encerrando.", limiteDiario, workerID)
+					return
+				}
+
+				//verificar limite por hora
+				es.atualizarJanelaHora()
+				if es.config.Limits.HourlyCap > 0 && es.contadores.hora >= es.config.Limits.HourlyCap {
+					es.mutex.Unlock()
+					log.Printf("⚠️ Limite horário de %d emails atingido. Worker %d encerrando.", es.config.Limits.HourlyCap, workerID)
 					return
 				}
 				es.mutex.Unlock()
 
-				es.EnviarEmail(aluno)
+				//aguardar o limitador de taxa (token bucket) antes de enviar
+				if err := es.limiter.Wait(ctx); err != nil {
+					log.Printf("🛑 Limitador de taxa interrompido: %v. Worker %d encerrando.", err, workerID)
+					return
+				}
 
-				//delay entre email
-				time.Sleep(time.Duration(es.config.Limits.DelaySeconds))
+				es.EnviarEmail(ctx, aluno)
 			}
 		}(i)
 	}
@@ -277,8 +761,32 @@ func (es *EmailSender) EnviarEmailsEmLote(alunos []Aluno) {
 	log.Printf("📈 Envio concluído: %d enviados, %d falhas", es.contadores.enviados, es.contadores.falhas)
 }
 
+// atualizarEstatisticasTracking recarrega opened_at/clicks do armazenamento
+// de estado para cada resultado de sucesso, refletindo eventos registrados
+// pelo servidor de tracking (aberturas, cliques) desde o envio.
+func (es *EmailSender) atualizarEstatisticasTracking() {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	for i := range es.resultados {
+		r := &es.resultados[i]
+		if r.Status != "sucesso" {
+			continue
+		}
+		messageID := state.MessageID(es.campaignID, r.Aluno.Matricula)
+		rec, found, err := es.store.Get(es.campaignID, messageID)
+		if err != nil || !found {
+			continue
+		}
+		r.OpenedAt = rec.OpenedAt
+		r.Clicks = rec.Clicks
+	}
+}
+
 // salvarResultados salva resultados em json
 func (es *EmailSender) SalvarResultados(caminho string) error {
+	es.atualizarEstatisticasTracking()
+
 	es.mutex.Lock()
 	defer es.mutex.Unlock()
 
@@ -305,6 +813,8 @@ func (es *EmailSender) SalvarResultados(caminho string) error {
 }
 
 func (es *EmailSender) GerarRelatorio() {
+	es.atualizarEstatisticasTracking()
+
 	es.mutex.Lock()
 	defer es.mutex.Unlock()
 
@@ -315,16 +825,76 @@ func (es *EmailSender) GerarRelatorio() {
 	log.Printf("Falhas: %d", es.contadores.falhas)
 	log.Printf("Taxa de sucesso: %.2f%%",
 		float64(es.contadores.enviados)/float64(len(es.resultados))*100)
+	log.Printf("Taxa efetiva de envio: %.3f emails/s", float64(es.limiter.Limit()))
+
+	if es.config.Tracking.Enabled && es.contadores.enviados > 0 {
+		abertos, cliques := 0, 0
+		for _, r := range es.resultados {
+			if !r.OpenedAt.IsZero() {
+				abertos++
+			}
+			cliques += len(r.Clicks)
+		}
+		log.Printf("Taxa de abertura: %.2f%%", float64(abertos)/float64(es.contadores.enviados)*100)
+		log.Printf("CTR (cliques por envio): %.2f%%", float64(cliques)/float64(es.contadores.enviados)*100)
+	}
 }
 
 func main() {
-	// inicializar sender
-	sender, err := NewEmailSender("config.yaml")
+	resume := flag.Bool("resume", false, "retoma uma campanha interrompida, reprocessando apenas pendências/falhas")
+	flag.Parse()
+
+	config, err := loadConfig("config.yaml")
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(config.Campaigns) == 0 {
+		executarEnvioUnico(ctx, config, *resume)
+		return
+	}
+
+	executarAgendador(ctx, config)
+}
+
+// trackingConfig traduz o bloco `tracking` do Config para o formato esperado
+// pelo pacote tracking.
+func trackingConfig(config Config) tracking.Config {
+	return tracking.Config{
+		ListenAddr:  config.Tracking.ListenAddr,
+		TLSCertPath: config.Tracking.TLSCertPath,
+		TLSKeyPath:  config.Tracking.TLSKeyPath,
+		HMACSecret:  config.Tracking.HMACSecret,
+	}
+}
+
+// executarEnvioUnico preserva o comportamento original do programa: carrega
+// um único CSV, envia um lote e encerra. Usado quando `campaigns` não está
+// declarado em config.yaml. Quando `tracking.enabled` está ativo, o servidor
+// de tracking continua no ar após o envio, registrando aberturas e cliques
+// até o processo receber SIGINT/SIGTERM.
+func executarEnvioUnico(ctx context.Context, config Config, resume bool) {
+	sender, err := newEmailSenderFromConfig(config)
 	if err != nil {
 		log.Fatalf("Erro ao inicializar EmailSender: %v", err)
 	}
+	defer sender.Fechar()
+
+	var trackingWG sync.WaitGroup
+	if config.Tracking.Enabled {
+		trackingWG.Add(1)
+		go func() {
+			defer trackingWG.Done()
+			srv := tracking.NewServer(trackingConfig(config), sender.store)
+			if err := srv.Run(ctx); err != nil {
+				log.Printf("⚠️ erro no servidor de tracking: %v", err)
+			}
+		}()
+	}
 
-	// carregar alunos do CSV
 	alunos, err := sender.CarregarAlunosCSV("alunos.csv")
 	if err != nil {
 		log.Fatalf("Erro ao carregar alunos do CSV: %v", err)
@@ -334,8 +904,14 @@ func main() {
 		log.Fatalf("Nenhum aluno válido encontrado no CSV.")
 	}
 
+	// processar bounces/reclamações pendentes antes de enviar, para não
+	// reenviar a destinatários já suprimidos
+	if err := sender.ProcessarBounces(alunos); err != nil {
+		log.Printf("Erro ao processar bounces: %v", err)
+	}
+
 	// enviar emails em lote
-	sender.EnviarEmailsEmLote(alunos)
+	sender.EnviarEmailsEmLote(ctx, alunos, resume)
 
 	// salvar resultados
 	err = sender.SalvarResultados("")
@@ -345,4 +921,117 @@ func main() {
 
 	// gerar relatório final
 	sender.GerarRelatorio()
+
+	if config.Tracking.Enabled {
+		log.Printf("🛰️ Servidor de tracking ativo em %s; aguardando Ctrl+C para encerrar.", config.Tracking.ListenAddr)
+		<-ctx.Done()
+		trackingWG.Wait()
+	}
+}
+
+// newEmailSenderFromConfig constrói o EmailSender padrão (campanha única) a
+// partir de um Config já carregado.
+func newEmailSenderFromConfig(config Config) (*EmailSender, error) {
+	provider, builder, store, err := buildDependenciasCompartilhadas(config)
+	if err != nil {
+		return nil, err
+	}
+
+	campaignID := config.Campaign.ID
+	if campaignID == "" {
+		campaignID = "default"
+	}
+
+	return newEmailSender(config, campaignID, config.Email.Template, config.Limits, provider, builder, store)
+}
+
+// executarAgendador transforma o programa em um serviço de longa duração:
+// constrói um EmailSender por campanha declarada em config.yaml,
+// compartilhando transporte e armazenamento de estado, e registra cada um
+// como um job cron. Encerra de forma graciosa ao receber SIGINT/SIGTERM,
+// aguardando campanhas em andamento terminarem antes de sair.
+func executarAgendador(ctx context.Context, config Config) {
+	provider, builder, store, err := buildDependenciasCompartilhadas(config)
+	if err != nil {
+		log.Fatalf("Erro ao inicializar dependências compartilhadas: %v", err)
+	}
+	defer store.Close()
+
+	if config.Tracking.Enabled {
+		go func() {
+			srv := tracking.NewServer(trackingConfig(config), store)
+			if err := srv.Run(ctx); err != nil {
+				log.Printf("⚠️ erro no servidor de tracking: %v", err)
+			}
+		}()
+	}
+
+	sched := scheduler.New(ctx)
+
+	for _, def := range config.Campaigns {
+		def := def
+		sender, err := newCampaignSender(config, def, provider, builder, store)
+		if err != nil {
+			log.Fatalf("Erro ao inicializar campanha %q: %v", def.Name, err)
+		}
+
+		err = sched.Add(scheduler.Job{
+			Name:     def.Name,
+			Schedule: def.Schedule,
+			Run: func(ctx context.Context) {
+				executarCampanha(ctx, sender, def)
+			},
+		})
+		if err != nil {
+			log.Fatalf("Erro ao agendar campanha %q: %v", def.Name, err)
+		}
+	}
+
+	log.Printf("🗓️ Agendador iniciado com %d campanha(s). Pressione Ctrl+C para encerrar.", len(config.Campaigns))
+	sched.Run()
+	log.Printf("🛑 Agendador encerrado.")
+}
+
+// idOcorrencia deriva o identificador de campanha usado na store para um
+// disparo agendado: combina o nome da campanha com a data do disparo, para
+// que cada ocorrência (ex.: a edição desta semana de uma newsletter
+// recorrente) tenha seu próprio espaço de dedup em vez de reutilizar para
+// sempre o estado "já enviado" da primeira execução. Reinícios no mesmo dia
+// (ex.: o processo caiu e o agendador foi reiniciado) continuam caindo na
+// mesma ocorrência, então os pendentes/falhas daquele disparo são retomados
+// em vez de reenviados do zero.
+func idOcorrencia(nome string, momento time.Time) string {
+	return fmt.Sprintf("%s-%s", nome, momento.Format("2006-01-02"))
+}
+
+// executarCampanha roda uma única ocorrência de uma campanha agendada:
+// recarrega o CSV a cada disparo (para refletir altas/baixas recentes),
+// processa bounces pendentes, envia o lote e salva os resultados. Cada
+// disparo usa seu próprio campaignID (ver idOcorrencia) para que uma
+// campanha recorrente envie a cada ocorrência, não só na primeira.
+func executarCampanha(ctx context.Context, sender *EmailSender, def CampaignDef) {
+	sender.campaignID = idOcorrencia(def.Name, time.Now())
+
+	alunos, err := sender.CarregarAlunosCSV(def.CSVPath)
+	if err != nil {
+		log.Printf("❌ Erro ao carregar CSV da campanha %q: %v", def.Name, err)
+		return
+	}
+	if len(alunos) == 0 {
+		log.Printf("⚠️ Nenhum aluno válido encontrado no CSV da campanha %q.", def.Name)
+		return
+	}
+
+	if err := sender.ProcessarBounces(alunos); err != nil {
+		log.Printf("Erro ao processar bounces da campanha %q: %v", def.Name, err)
+	}
+
+	sender.EnviarEmailsEmLote(ctx, alunos, false)
+
+	caminho := fmt.Sprintf("resultados_%s_%s.json", def.Name, time.Now().Format("20060102_150405"))
+	if err := sender.SalvarResultados(caminho); err != nil {
+		log.Printf("Erro ao salvar resultados da campanha %q: %v", def.Name, err)
+	}
+
+	sender.GerarRelatorio()
 }
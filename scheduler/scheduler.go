@@ -0,0 +1,69 @@
+// Package scheduler fires named jobs on cron schedules and coordinates a
+// graceful shutdown that waits for in-flight jobs to finish instead of
+// killing them mid-run.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is one cron-scheduled unit of work. Run receives a context that is
+// canceled when the scheduler begins shutting down.
+type Job struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context)
+}
+
+// Scheduler runs Jobs on their cron schedules and tracks in-flight runs so
+// shutdown can drain them gracefully.
+type Scheduler struct {
+	cron   *cron.Cron
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New builds a Scheduler whose jobs receive a context derived from ctx;
+// canceling ctx (or calling Stop) begins a graceful shutdown.
+func New(ctx context.Context) *Scheduler {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Scheduler{cron: cron.New(), ctx: ctx, cancel: cancel}
+}
+
+// Add registers job to fire on its cron schedule.
+func (s *Scheduler) Add(job Job) error {
+	_, err := s.cron.AddFunc(job.Schedule, func() {
+		s.wg.Add(1)
+		defer s.wg.Done()
+
+		log.Printf("🗓️ Executando campanha agendada: %s", job.Name)
+		job.Run(s.ctx)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run starts dispatching jobs and blocks until the scheduler's context is
+// canceled, then waits for any in-flight job to finish before returning.
+func (s *Scheduler) Run() {
+	s.cron.Start()
+	<-s.ctx.Done()
+
+	log.Printf("🛑 Encerrando agendador; aguardando campanhas em andamento...")
+	stopped := s.cron.Stop() // impede novos disparos
+	<-stopped.Done()
+	s.wg.Wait()
+}
+
+// Stop begins a graceful shutdown: no new jobs fire, and Run returns once
+// every in-flight job completes.
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdOcorrenciaVariaPorDia(t *testing.T) {
+	base := time.Date(2026, 7, 27, 9, 0, 0, 0, time.UTC)
+
+	primeiraOcorrencia := idOcorrencia("newsletter", base)
+	mesmaOcorrencia := idOcorrencia("newsletter", base.Add(2*time.Hour))
+	if mesmaOcorrencia != primeiraOcorrencia {
+		t.Errorf("idOcorrencia divergiu para disparos no mesmo dia: %q != %q", mesmaOcorrencia, primeiraOcorrencia)
+	}
+
+	proximaSemana := idOcorrencia("newsletter", base.AddDate(0, 0, 7))
+	if proximaSemana == primeiraOcorrencia {
+		t.Errorf("idOcorrencia não variou entre ocorrências em dias diferentes: %q", proximaSemana)
+	}
+}
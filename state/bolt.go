@@ -0,0 +1,95 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltStore persists campaign Records in a local BoltDB file, one bucket per
+// campaign id.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// OpenBoltStore opens (creating if needed) a BoltDB-backed Store at path.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco de estado: %v", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(campaignID, messageID string) (Record, bool, error) {
+	var rec Record
+	found := false
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(campaignID))
+		if bucket == nil {
+			return nil
+		}
+		raw := bucket.Get([]byte(messageID))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &rec)
+	})
+	if err != nil {
+		return Record{}, false, fmt.Errorf("erro ao ler estado de %s: %v", messageID, err)
+	}
+	return rec, found, nil
+}
+
+func (s *BoltStore) FindByRecipient(campaignID, recipient string) (Record, bool, error) {
+	var rec Record
+	found := false
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(campaignID))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, raw []byte) error {
+			if found {
+				return nil
+			}
+			var candidata Record
+			if err := json.Unmarshal(raw, &candidata); err != nil {
+				return err
+			}
+			if candidata.Recipient == recipient {
+				rec = candidata
+				found = true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return Record{}, false, fmt.Errorf("erro ao buscar estado do destinatário %s: %v", recipient, err)
+	}
+	return rec, found, nil
+}
+
+func (s *BoltStore) Put(campaignID string, rec Record) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar estado: %v", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(campaignID))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(rec.MessageID), raw)
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
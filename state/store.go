@@ -0,0 +1,63 @@
+// Package state persists per-recipient delivery progress so long campaigns
+// can be resumed after a crash instead of risking duplicate sends.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Status is the delivery lifecycle of a single recipient within a campaign.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusSent       Status = "sent"
+	StatusFailed     Status = "failed"
+	StatusBounced    Status = "bounced"
+	StatusComplained Status = "complained"
+)
+
+// ClickEvent records a single click on a tracked link within a sent email.
+type ClickEvent struct {
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"ts"`
+}
+
+// Record tracks one recipient's delivery attempts within a campaign.
+type Record struct {
+	MessageID string
+	Recipient string // endereço de email, usado por FindByRecipient
+	Status    Status
+	Attempts  int
+	LastError string
+	NextRetry time.Time
+
+	// OpenedAt e Clicks são preenchidos pelo servidor de tracking (pacote
+	// tracking) conforme o destinatário abre o email ou clica em links
+	// rastreados; ficam zerados para destinatários com opt-out (DoNotTrack).
+	OpenedAt time.Time    `json:",omitempty"`
+	Clicks   []ClickEvent `json:",omitempty"`
+}
+
+// Store persists Records keyed by campaign and message id.
+type Store interface {
+	// Get returns the Record for messageID within campaignID, or found=false
+	// if no attempt has been recorded yet.
+	Get(campaignID, messageID string) (rec Record, found bool, err error)
+	// FindByRecipient looks up the Record for a recipient email address
+	// within a campaign. Used when only the address is known, e.g. when
+	// ingesting bounce/complaint reports.
+	FindByRecipient(campaignID, recipient string) (rec Record, found bool, err error)
+	// Put records rec for campaignID, overwriting any previous attempt.
+	Put(campaignID string, rec Record) error
+	Close() error
+}
+
+// MessageID derives a stable id for a recipient within a campaign, so the
+// same recipient maps to the same Record across runs and retries.
+func MessageID(campaignID, matricula string) string {
+	sum := sha256.Sum256([]byte(campaignID + ":" + matricula))
+	return hex.EncodeToString(sum[:])
+}
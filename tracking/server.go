@@ -0,0 +1,159 @@
+// Package tracking runs the embedded HTTP server that records opens and
+// clicks reported by recipients of tracked emails into the same state.Store
+// used for delivery status.
+package tracking
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Josehpequeno/email-sender/state"
+)
+
+// Config holds the embedded tracking HTTP server's listen address, optional
+// TLS certificate pair, and the HMAC secret used to sign/verify tokens.
+type Config struct {
+	ListenAddr  string
+	TLSCertPath string
+	TLSKeyPath  string
+	HMACSecret  string
+}
+
+// Server records opens (GET /o/{campaignID}/{token}.gif) and clicks
+// (GET /c/{campaignID}/{token}?u=<base64 original>) into a state.Store.
+type Server struct {
+	cfg   Config
+	store state.Store
+}
+
+// NewServer builds a tracking Server backed by store.
+func NewServer(cfg Config, store state.Store) *Server {
+	return &Server{cfg: cfg, store: store}
+}
+
+// pixelGIF is a 1x1 transparent GIF served for every open callback,
+// regardless of whether the token was valid, so recipients never see a
+// broken image.
+var pixelGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00,
+	0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+	0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// Run starts the tracking HTTP server and blocks until ctx is canceled,
+// shutting down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/o/", s.handleOpen)
+	mux.HandleFunc("/c/", s.handleClick)
+
+	srv := &http.Server{Addr: s.cfg.ListenAddr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if s.cfg.TLSCertPath != "" {
+			err = srv.ListenAndServeTLS(s.cfg.TLSCertPath, s.cfg.TLSKeyPath)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *Server) handleOpen(w http.ResponseWriter, r *http.Request) {
+	caminho := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/o/"), ".gif")
+	campaignID, token, ok := splitCampanhaToken(caminho)
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(pixelGIF)
+
+	if !ok {
+		return
+	}
+	messageID, valido := ParseToken(s.cfg.HMACSecret, token)
+	if !valido {
+		log.Printf("⚠️ token de abertura inválido recebido para campanha %s", campaignID)
+		return
+	}
+	s.registrarAbertura(campaignID, messageID)
+}
+
+func (s *Server) handleClick(w http.ResponseWriter, r *http.Request) {
+	campaignID, token, ok := splitCampanhaToken(strings.TrimPrefix(r.URL.Path, "/c/"))
+
+	destino := "/"
+	if u := r.URL.Query().Get("u"); u != "" {
+		if raw, err := base64.URLEncoding.DecodeString(u); err == nil && len(raw) > 0 {
+			destino = string(raw)
+		}
+	}
+
+	if ok {
+		if messageID, valido := ParseToken(s.cfg.HMACSecret, token); valido {
+			s.registrarClique(campaignID, messageID, destino)
+		} else {
+			log.Printf("⚠️ token de clique inválido recebido para campanha %s", campaignID)
+		}
+	}
+
+	http.Redirect(w, r, destino, http.StatusFound)
+}
+
+func splitCampanhaToken(caminho string) (campaignID, token string, ok bool) {
+	campaignID, token, found := strings.Cut(caminho, "/")
+	if !found || campaignID == "" || token == "" {
+		return "", "", false
+	}
+	return campaignID, token, true
+}
+
+func (s *Server) registrarAbertura(campaignID, messageID string) {
+	rec, _, err := s.store.Get(campaignID, messageID)
+	if err != nil {
+		log.Printf("⚠️ erro ao consultar estado para registrar abertura: %v", err)
+		return
+	}
+	if rec.MessageID == "" {
+		rec.MessageID = messageID
+	}
+	if rec.OpenedAt.IsZero() {
+		rec.OpenedAt = time.Now()
+	}
+	if err := s.store.Put(campaignID, rec); err != nil {
+		log.Printf("⚠️ erro ao persistir abertura: %v", err)
+	}
+}
+
+func (s *Server) registrarClique(campaignID, messageID, url string) {
+	rec, _, err := s.store.Get(campaignID, messageID)
+	if err != nil {
+		log.Printf("⚠️ erro ao consultar estado para registrar clique: %v", err)
+		return
+	}
+	if rec.MessageID == "" {
+		rec.MessageID = messageID
+	}
+	rec.Clicks = append(rec.Clicks, state.ClickEvent{URL: url, Timestamp: time.Now()})
+	if err := s.store.Put(campaignID, rec); err != nil {
+		log.Printf("⚠️ erro ao persistir clique: %v", err)
+	}
+}
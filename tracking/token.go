@@ -0,0 +1,41 @@
+package tracking
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Token produces an HMAC-signed, URL-safe identifier embedding messageID, so
+// the tracking server can recover it from an open/click callback without
+// trusting the caller: forging a valid token for another recipient requires
+// knowing secret.
+func Token(secret, messageID string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(messageID))
+	return payload + "." + sign(secret, payload)
+}
+
+// ParseToken verifies token's HMAC signature and returns the messageID
+// embedded in it. ok is false if the signature is missing or invalid.
+func ParseToken(secret, token string) (messageID string, ok bool) {
+	payload, sig, found := strings.Cut(token, ".")
+	if !found {
+		return "", false
+	}
+	if !hmac.Equal([]byte(sign(secret, payload)), []byte(sig)) {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
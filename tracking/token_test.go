@@ -0,0 +1,45 @@
+package tracking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	secret := "segredo-super-secreto"
+	messageID := "abc123def456"
+
+	token := Token(secret, messageID)
+
+	got, ok := ParseToken(secret, token)
+	if !ok {
+		t.Fatalf("ParseToken(%q) falhou em validar um token recém-gerado", token)
+	}
+	if got != messageID {
+		t.Errorf("ParseToken retornou messageID = %q, esperado %q", got, messageID)
+	}
+}
+
+func TestParseTokenRejeitaAssinaturaInvalida(t *testing.T) {
+	token := Token("segredo-a", "abc123")
+
+	if _, ok := ParseToken("segredo-b", token); ok {
+		t.Errorf("ParseToken aceitou um token assinado com outro segredo")
+	}
+}
+
+func TestParseTokenRejeitaFormatoInvalido(t *testing.T) {
+	if _, ok := ParseToken("segredo", "sem-ponto-separador"); ok {
+		t.Errorf("ParseToken aceitou um token sem o separador payload.assinatura")
+	}
+}
+
+func TestParseTokenRejeitaPayloadAdulterado(t *testing.T) {
+	token := Token("segredo", "abc123")
+	payload, sig, _ := strings.Cut(token, ".")
+
+	adulterado := payload + "x" + "." + sig
+	if _, ok := ParseToken("segredo", adulterado); ok {
+		t.Errorf("ParseToken aceitou um token com payload adulterado")
+	}
+}